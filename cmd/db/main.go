@@ -0,0 +1,183 @@
+// Command db is a small developer tool for provisioning and migrating the MySQL
+// database used by local tests, without paying the cold-start cost of launching
+// a Docker container on every `go test` invocation.
+//
+// Usage:
+//
+//	db setup [--force]
+//	db migrate
+//	db drop --force
+//
+// Connection details are read from the environment:
+//
+//	MYSQL_USER, MYSQL_PASSWORD, MYSQL_ADDRESS, MYSQL_DATABASE, MYSQL_ROOT_PASSWORD
+package main
+
+import (
+	"context"
+	gosql "database/sql"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/darwinz/base/database"
+	"github.com/darwinz/base/log"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "setup":
+		fs := flag.NewFlagSet("setup", flag.ExitOnError)
+		force := fs.Bool("force", false, "recreate the database if it already exists")
+		fs.Parse(os.Args[2:])
+
+		err = setup(config, *force)
+	case "migrate":
+		fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+		fs.Parse(os.Args[2:])
+
+		err = migrateOnly(config)
+	case "drop":
+		fs := flag.NewFlagSet("drop", flag.ExitOnError)
+		force := fs.Bool("force", false, "required to actually drop the database")
+		fs.Parse(os.Args[2:])
+
+		err = dropCommand(config, *force)
+	default:
+		usage()
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: db <setup|migrate|drop> [--force]")
+}
+
+func loadConfig() (database.DatabaseConfig, error) {
+	dbName := os.Getenv("MYSQL_DATABASE")
+	if dbName == "" {
+		return database.DatabaseConfig{}, fmt.Errorf("MYSQL_DATABASE is required")
+	}
+
+	return database.DatabaseConfig{
+		DatabaseName: dbName,
+		MySQL: &database.MySQLConfig{
+			Address:  os.Getenv("MYSQL_ADDRESS"),
+			User:     os.Getenv("MYSQL_USER"),
+			Password: os.Getenv("MYSQL_PASSWORD"),
+		},
+	}, nil
+}
+
+// setup creates the database and its user/grants (unless they already exist) and
+// then runs migrations against it.
+func setup(config database.DatabaseConfig, force bool) error {
+	rootPassword := os.Getenv("MYSQL_ROOT_PASSWORD")
+	if rootPassword == "" {
+		rootPassword = config.MySQL.Password
+	}
+
+	rootConfig := &database.DatabaseConfig{
+		MySQL: &database.MySQLConfig{
+			Address:  config.MySQL.Address,
+			Password: rootPassword,
+		},
+	}
+
+	if force {
+		if err := dropDatabase(rootConfig, config.DatabaseName); err != nil {
+			return err
+		}
+	}
+
+	if err := createDatabaseAndUser(rootConfig, config.DatabaseName, config.MySQL.User, config.MySQL.Password); err != nil {
+		return fmt.Errorf("creating database: %w", err)
+	}
+
+	return migrateOnly(config)
+}
+
+func migrateOnly(config database.DatabaseConfig) error {
+	logger := log.NewLogfmtLogger(os.Stderr)
+
+	if err := database.RunMigrations(logger, config); err != nil {
+		return fmt.Errorf("running migrations: %w", err)
+	}
+
+	return nil
+}
+
+// dropCommand refuses to drop config's database unless force is set, as a
+// safety net against accidentally dropping a database from a bare `db drop`.
+func dropCommand(config database.DatabaseConfig, force bool) error {
+	if !force {
+		return fmt.Errorf("refusing to drop %s without --force", config.DatabaseName)
+	}
+	return drop(config)
+}
+
+func drop(config database.DatabaseConfig) error {
+	rootPassword := os.Getenv("MYSQL_ROOT_PASSWORD")
+	if rootPassword == "" {
+		rootPassword = config.MySQL.Password
+	}
+
+	return dropDatabase(&database.DatabaseConfig{
+		MySQL: &database.MySQLConfig{
+			Address:  config.MySQL.Address,
+			Password: rootPassword,
+		},
+	}, config.DatabaseName)
+}
+
+func dropDatabase(rootConfig *database.DatabaseConfig, dbName string) error {
+	dsn := fmt.Sprintf("%s:%s@%s/", "root", rootConfig.MySQL.Password, rootConfig.MySQL.Address)
+	db, err := gosql.Open("mysql", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.ExecContext(context.Background(), fmt.Sprintf("drop database if exists %s", dbName))
+	return err
+}
+
+// createDatabaseAndUser creates dbName (if it doesn't already exist) and grants
+// the given user full access to it.
+func createDatabaseAndUser(rootConfig *database.DatabaseConfig, dbName, user, password string) error {
+	dsn := fmt.Sprintf("%s:%s@%s/", "root", rootConfig.MySQL.Password, rootConfig.MySQL.Address)
+	db, err := gosql.Open("mysql", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("create database if not exists %s", dbName)); err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("create user if not exists '%s'@'%%' identified by '%s'", user, password)); err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, fmt.Sprintf("grant all on %s.* to '%s'@'%%'", dbName, user))
+	return err
+}
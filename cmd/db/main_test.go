@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/darwinz/base/database"
+)
+
+func TestDropCommand_RequiresForce(t *testing.T) {
+	config := database.DatabaseConfig{DatabaseName: "testdb"}
+
+	err := dropCommand(config, false)
+	if err == nil {
+		t.Fatal("expected an error when force is false")
+	}
+	if !strings.Contains(err.Error(), "testdb") || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected error to mention the database name and --force, got: %v", err)
+	}
+}
+
+func TestDropCommand_ForceStillFails(t *testing.T) {
+	config := database.DatabaseConfig{
+		DatabaseName: "testdb",
+		MySQL:        &database.MySQLConfig{Address: "tcp(127.0.0.1:1)"},
+	}
+
+	// force=true should attempt the drop (and fail, since there's nothing
+	// listening), rather than being rejected by the --force gate.
+	err := dropCommand(config, true)
+	if err == nil {
+		t.Fatal("expected a connection error since nothing is listening")
+	}
+	if strings.Contains(err.Error(), "--force") {
+		t.Fatalf("force=true should not be rejected by the --force gate, got: %v", err)
+	}
+}
+
+func TestLoadConfig_RequiresDatabaseName(t *testing.T) {
+	t.Setenv("MYSQL_DATABASE", "")
+
+	_, err := loadConfig()
+	if err == nil {
+		t.Fatal("expected an error when MYSQL_DATABASE is unset")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("MYSQL_DATABASE", "mydb")
+	t.Setenv("MYSQL_USER", "myuser")
+	t.Setenv("MYSQL_PASSWORD", "mypass")
+	t.Setenv("MYSQL_ADDRESS", "tcp(localhost:3306)")
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.DatabaseName != "mydb" {
+		t.Errorf("DatabaseName = %q, want %q", config.DatabaseName, "mydb")
+	}
+	if config.MySQL.User != "myuser" || config.MySQL.Password != "mypass" || config.MySQL.Address != "tcp(localhost:3306)" {
+		t.Errorf("unexpected MySQL config: %+v", config.MySQL)
+	}
+}
@@ -0,0 +1,13 @@
+package database
+
+// ErrorClassifier lets callers test a driver error against common classes of
+// database failure (a unique constraint, a deadlock, ...) without importing
+// dialect-specific helpers like MySQLUniqueViolation directly. Use DB.Errors()
+// to get the classifier for the dialect a connection was opened against.
+type ErrorClassifier interface {
+	UniqueViolation(err error) bool
+	DeadlockDetected(err error) bool
+	SerializationFailure(err error) bool
+	NotNullViolation(err error) bool
+	ForeignKeyViolation(err error) bool
+}
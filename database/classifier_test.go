@@ -0,0 +1,63 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+func TestMySQLErrorClassifier(t *testing.T) {
+	c := mySQLErrorClassifier{}
+
+	cases := []struct {
+		name  string
+		err   error
+		check func(error) bool
+		want  bool
+	}{
+		{"unique violation matches", &gomysql.MySQLError{Number: mySQLErrDuplicateKey}, c.UniqueViolation, true},
+		{"unique violation mismatches", &gomysql.MySQLError{Number: mySQLErrDeadlock}, c.UniqueViolation, false},
+		{"deadlock detected matches", &gomysql.MySQLError{Number: mySQLErrDeadlock}, c.DeadlockDetected, true},
+		{"serialization failure maps to deadlock code", &gomysql.MySQLError{Number: mySQLErrDeadlock}, c.SerializationFailure, true},
+		{"not null violation matches", &gomysql.MySQLError{Number: mySQLErrDataTruncatedNull}, c.NotNullViolation, true},
+		{"foreign key violation matches", &gomysql.MySQLError{Number: mySQLErrForeignKeyParent}, c.ForeignKeyViolation, true},
+		{"non-mysql error never matches", errors.New("boom"), c.UniqueViolation, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.check(tc.err); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPostgresErrorClassifier(t *testing.T) {
+	c := postgresErrorClassifier{}
+
+	cases := []struct {
+		name  string
+		err   error
+		check func(error) bool
+		want  bool
+	}{
+		{"unique violation matches", &pq.Error{Code: pq.ErrorCode(postgresErrUniqueViolation)}, c.UniqueViolation, true},
+		{"unique violation mismatches", &pq.Error{Code: pq.ErrorCode(postgresErrDeadlockDetected)}, c.UniqueViolation, false},
+		{"deadlock detected matches", &pq.Error{Code: pq.ErrorCode(postgresErrDeadlockDetected)}, c.DeadlockDetected, true},
+		{"serialization failure matches", &pq.Error{Code: pq.ErrorCode(postgresErrSerializationFailure)}, c.SerializationFailure, true},
+		{"not null violation matches", &pq.Error{Code: pq.ErrorCode(postgresErrNotNullViolation)}, c.NotNullViolation, true},
+		{"foreign key violation matches", &pq.Error{Code: pq.ErrorCode(postgresErrForeignKeyViolation)}, c.ForeignKeyViolation, true},
+		{"non-postgres error never matches", errors.New("boom"), c.UniqueViolation, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.check(tc.err); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
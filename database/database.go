@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/darwinz/base/log"
+)
+
+// DB wraps a *sql.DB together with an ErrorClassifier for the dialect it was
+// opened against, so callers can write db.Errors().UniqueViolation(err) instead
+// of importing a dialect-specific helper like MySQLUniqueViolation directly.
+type DB struct {
+	*sql.DB
+	errors ErrorClassifier
+}
+
+// Errors returns the ErrorClassifier for this connection's dialect.
+func (d *DB) Errors() ErrorClassifier {
+	return d.errors
+}
+
+// New establishes a database connection according to the DatabaseConfig provided.
+func New(ctx context.Context, logger log.Logger, config DatabaseConfig) (*DB, error) {
+	retry := retryPolicy(config)
+
+	if config.MySQL != nil {
+		conn := mysqlConnection(logger, retry, config.MySQL.User, config.MySQL.Password, config.MySQL.Address, config.DatabaseName)
+		db, err := conn.Connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &DB{DB: db, errors: mySQLErrorClassifier{}}, nil
+	} else if config.Postgres != nil {
+		conn := postgresConnection(logger, retry, config.Postgres.User, config.Postgres.Password, config.Postgres.Address, config.DatabaseName)
+		db, err := conn.Connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &DB{DB: db, errors: postgresErrorClassifier{}}, nil
+	}
+	return nil, fmt.Errorf("database config not defined")
+}
+
+// NewAndMigrate establishes a database connection and runs migrations against it before returning.
+func NewAndMigrate(ctx context.Context, logger log.Logger, config DatabaseConfig) (*DB, error) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := RunMigrations(logger, config); err != nil {
+		return nil, err
+	}
+
+	db, err := New(ctx, logger, config)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
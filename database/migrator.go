@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/golang-migrate/migrate/v4"
+	migdb "github.com/golang-migrate/migrate/v4/database"
+	migmysql "github.com/golang-migrate/migrate/v4/database/mysql"
+	migpostgres "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+
+	"github.com/darwinz/base/log"
+)
+
+var migrationMutex sync.Mutex
+
+// RunMigrations runs any pending migrations against the database described by config.
+func RunMigrations(logger log.Logger, config DatabaseConfig) error {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+
+	db, err := New(context.Background(), logger, config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var source source.Driver
+	var driver migdb.Driver
+	err = withRetry(context.Background(), retryPolicy(config), logger, func() error {
+		source, driver, err = GetDriver(db.DB, config)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	migrationMutex.Lock()
+	defer migrationMutex.Unlock()
+
+	m, err := migrate.NewWithInstance("pkger", source, config.DatabaseName, driver)
+	if err != nil {
+		return fmt.Errorf("setting up migrations: %w", err)
+	}
+
+	logger.Info(context.Background(), "running migrations")
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("running migrations: %w", err)
+	}
+
+	return nil
+}
+
+// GetDriver returns the migration source and database driver for the given DatabaseConfig.
+func GetDriver(db *sql.DB, config DatabaseConfig) (source.Driver, migdb.Driver, error) {
+	if config.MySQL != nil {
+		src, err := NewPkgerSource("mysql")
+		if err != nil {
+			return nil, nil, err
+		}
+		drv, err := migmysql.WithInstance(db, &migmysql.Config{})
+		if err != nil {
+			return nil, nil, err
+		}
+		return src, drv, nil
+	} else if config.Postgres != nil {
+		src, err := NewPkgerSource("postgres")
+		if err != nil {
+			return nil, nil, err
+		}
+		drv, err := migpostgres.WithInstance(db, &migpostgres.Config{})
+		if err != nil {
+			return nil, nil, err
+		}
+		return src, drv, nil
+	}
+	return nil, nil, fmt.Errorf("database config not defined")
+}
@@ -0,0 +1,26 @@
+package database
+
+// DatabaseConfig defines connection details for each database type we support.
+// Exactly one of MySQL or Postgres should be set.
+type DatabaseConfig struct {
+	MySQL        *MySQLConfig
+	Postgres     *PostgresConfig
+	DatabaseName string
+
+	// Retry overrides DefaultRetryPolicy for this connection/migration attempt.
+	Retry *RetryPolicy
+}
+
+// MySQLConfig contains the connection details for a MySQL instance.
+type MySQLConfig struct {
+	Address  string
+	User     string
+	Password string
+}
+
+// PostgresConfig contains the connection details for a PostgreSQL instance.
+type PostgresConfig struct {
+	Address  string
+	User     string
+	Password string
+}
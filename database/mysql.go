@@ -3,7 +3,6 @@ package database
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -12,18 +11,19 @@ import (
 	"testing"
 	"time"
 
+	"github.com/docker/go-connections/nat"
 	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
 
 	"github.com/moov-io/base"
 	"github.com/moov-io/base/docker"
 
 	kitprom "github.com/go-kit/kit/metrics/prometheus"
 	gomysql "github.com/go-sql-driver/mysql"
-	"github.com/ory/dockertest/v3"
-	dc "github.com/ory/dockertest/v3/docker"
 	stdprom "github.com/prometheus/client_golang/prometheus"
 
-	"github.com/moov-io/base/log"
+	"github.com/darwinz/base/log"
 )
 
 var (
@@ -46,6 +46,19 @@ var (
 	}()
 )
 
+// statsLogInterval returns how often the connection pool stats goroutine logs
+// (and records metrics for) a database connection pool, configurable via
+// DB_STATS_LOG_INTERVAL (e.g. "30s"). Shared by both the MySQL and Postgres
+// connectors.
+func statsLogInterval() time.Duration {
+	if v := os.Getenv("DB_STATS_LOG_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return 1 * time.Minute
+}
+
 type discardLogger struct{}
 
 func (l discardLogger) Print(v ...interface{}) {}
@@ -57,6 +70,7 @@ func init() {
 type mysql struct {
 	dsn    string
 	logger log.Logger
+	retry  RetryPolicy
 
 	connections *kitprom.Gauge
 }
@@ -68,14 +82,15 @@ func (my *mysql) Connect(ctx context.Context) (*sql.DB, error) {
 	}
 	db.SetMaxOpenConns(maxActiveMySQLConnections)
 
-	// Check out DB is up and working
-	if err := db.Ping(); err != nil {
+	// Check our DB is up and working, retrying with backoff since the MySQL
+	// sidecar or RDS instance may not be ready yet when the app boots.
+	if err := withRetry(ctx, my.retry, my.logger, db.Ping); err != nil {
 		return nil, err
 	}
 
 	// Setup metrics after the database is setup
 	go func() {
-		t := time.NewTicker(1 * time.Minute)
+		t := time.NewTicker(statsLogInterval())
 		for {
 			select {
 			case <-ctx.Done():
@@ -85,6 +100,12 @@ func (my *mysql) Connect(ctx context.Context) (*sql.DB, error) {
 				my.connections.With("state", "idle").Set(float64(stats.Idle))
 				my.connections.With("state", "inuse").Set(float64(stats.InUse))
 				my.connections.With("state", "open").Set(float64(stats.OpenConnections))
+
+				my.logger.Info(ctx, "mysql connection pool stats",
+					log.F("idle", log.Int(stats.Idle)),
+					log.F("inuse", log.Int(stats.InUse)),
+					log.F("open", log.Int(stats.OpenConnections)),
+				)
 			}
 		}
 	}()
@@ -92,7 +113,11 @@ func (my *mysql) Connect(ctx context.Context) (*sql.DB, error) {
 	return db, nil
 }
 
-func mysqlConnection(logger log.Logger, user, pass string, address string, database string) *mysql {
+func mysqlConnection(logger log.Logger, retry RetryPolicy, user, pass string, address string, database string) *mysql {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+
 	timeout := "30s"
 	if v := os.Getenv("MYSQL_TIMEOUT"); v != "" {
 		timeout = v
@@ -102,6 +127,7 @@ func mysqlConnection(logger log.Logger, user, pass string, address string, datab
 	return &mysql{
 		dsn:         dsn,
 		logger:      logger,
+		retry:       retry,
 		connections: mysqlConnections,
 	}
 }
@@ -109,7 +135,7 @@ func mysqlConnection(logger log.Logger, user, pass string, address string, datab
 // TestMySQLDB is a wrapper around sql.DB for MySQL connections designed for tests to provide
 // a clean database for each testcase.  Callers should cleanup with Close() when finished.
 type TestMySQLDB struct {
-	*sql.DB
+	*DB
 	name     string
 	shutdown func() // context shutdown func
 	t        *testing.T
@@ -144,7 +170,36 @@ type mySQLServer struct {
 	Config *DatabaseConfig
 
 	start     sync.Once
-	container *dockertest.Resource
+	container testcontainers.Container
+
+	substituteImage func(image string) string
+}
+
+// SubstituteImage registers fn to rewrite the MySQL image reference before it's pulled,
+// which lets air-gapped or enterprise CI environments redirect to an internal mirror.
+// If unset, the MOOV_TEST_IMAGE_REGISTRY environment variable is consulted instead.
+func (m *mySQLServer) SubstituteImage(fn func(image string) string) {
+	m.substituteImage = fn
+}
+
+func (m *mySQLServer) image() string {
+	return resolveMySQLImage(m.substituteImage)
+}
+
+// resolveMySQLImage returns the MySQL image to launch, preferring an explicit
+// substitute func and falling back to MOOV_TEST_IMAGE_REGISTRY.
+func resolveMySQLImage(substitute func(image string) string) string {
+	image := "mysql:8.0"
+	if substitute != nil {
+		return substitute(image)
+	}
+	if registry := os.Getenv("MOOV_TEST_IMAGE_REGISTRY"); registry != "" {
+		if idx := strings.LastIndex(image, "/"); idx >= 0 {
+			return registry + "/" + image[idx+1:]
+		}
+		return registry + "/" + image
+	}
+	return image
 }
 
 // Start starts MySQL server or finds running server (container) we do not stop
@@ -154,7 +209,7 @@ func (m *mySQLServer) Start() error {
 	var err error
 
 	m.start.Do(func() {
-		m.Config, m.container, err = RunMySQLDockerInstance(&DatabaseConfig{})
+		m.Config, m.container, err = runMySQLDockerInstance(&DatabaseConfig{}, m.image())
 	})
 
 	return err
@@ -163,30 +218,45 @@ func (m *mySQLServer) Start() error {
 // Stop stops container and removes linked volumes
 // We don't Stop MySQL to reduce startup time for the next test runs
 func (m *mySQLServer) Stop() error {
-	return m.container.Close()
+	return m.container.Terminate(context.Background())
 }
 
 // CreateTestMySQLDB returns a TestMySQLDB which can be used in tests
 // as a clean mysql database. All migrations are ran on the db before.
 //
+// If MOOV_TEST_EXTERNAL_MYSQL_DSN is set it is used to reach an already-running MySQL
+// server (e.g. a GitHub Actions services: container) instead of launching one via Docker,
+// which removes the container cold-start from test runs.
+//
 // Callers should call close on the returned *TestMySQLDB.
 func CreateTestMySQLDB(t *testing.T) *TestMySQLDB {
 	if testing.Short() {
 		t.Skip("-short flag enabled")
 	}
-	if !docker.Enabled() {
-		t.Skip("Docker not enabled")
-	}
 
-	err := SharedMySQL.Start()
-	require.NoError(t, err)
+	var config *DatabaseConfig
+	if dsn := os.Getenv("MOOV_TEST_EXTERNAL_MYSQL_DSN"); dsn != "" {
+		var err error
+		config, err = externalMySQLConfig(dsn)
+		require.NoError(t, err)
+	} else {
+		if !docker.Enabled() {
+			t.Skip("Docker not enabled")
+		}
 
-	dbName, err := CreateTemporaryDatabase(SharedMySQL.Config)
+		err := SharedMySQL.Start()
+		require.NoError(t, err)
+
+		config = SharedMySQL.Config
+	}
+
+	dbName, err := CreateTemporaryDatabase(config)
 	require.NoError(t, err)
 
 	dbConfig := &DatabaseConfig{
 		DatabaseName: dbName,
-		MySQL:        SharedMySQL.Config.MySQL,
+		MySQL:        config.MySQL,
+		Retry:        &FastRetryPolicy,
 	}
 
 	logger := log.NewNopLogger()
@@ -207,6 +277,24 @@ func CreateTestMySQLDB(t *testing.T) *TestMySQLDB {
 	}
 }
 
+// externalMySQLConfig parses a MOOV_TEST_EXTERNAL_MYSQL_DSN value (e.g.
+// "root:secret@tcp(localhost:3306)/") into a DatabaseConfig pointed at an
+// already-running MySQL server.
+func externalMySQLConfig(dsn string) (*DatabaseConfig, error) {
+	cfg, err := gomysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parsing MOOV_TEST_EXTERNAL_MYSQL_DSN: %w", err)
+	}
+
+	return &DatabaseConfig{
+		MySQL: &MySQLConfig{
+			Address:  fmt.Sprintf("%s(%s)", cfg.Net, cfg.Addr),
+			User:     cfg.User,
+			Password: cfg.Passwd,
+		},
+	}, nil
+}
+
 // We connect as root to MySQL server and create database with random name to
 // run our migrations on it later.
 func CreateTemporaryDatabase(config *DatabaseConfig) (string, error) {
@@ -232,7 +320,19 @@ func CreateTemporaryDatabase(config *DatabaseConfig) (string, error) {
 	return dbName, nil
 }
 
-func RunMySQLDockerInstance(config *DatabaseConfig) (*DatabaseConfig, *dockertest.Resource, error) {
+// RunMySQLDockerInstance launches (or reuses) a MySQL container via testcontainers-go
+// and returns a DatabaseConfig pointed at it.
+//
+// BREAKING CHANGE: this used to return a *dockertest.Resource as its second value.
+// Since this package moved its container orchestration to testcontainers-go, it now
+// returns a testcontainers.Container instead. Callers that type-asserted or otherwise
+// depended on *dockertest.Resource (e.g. calling resource.Close()) need to switch to
+// the testcontainers.Container API (e.g. container.Terminate(ctx)).
+func RunMySQLDockerInstance(config *DatabaseConfig) (*DatabaseConfig, testcontainers.Container, error) {
+	return runMySQLDockerInstance(config, resolveMySQLImage(nil))
+}
+
+func runMySQLDockerInstance(config *DatabaseConfig, image string) (*DatabaseConfig, testcontainers.Container, error) {
 	if config.MySQL == nil {
 		config.MySQL = &MySQLConfig{}
 	}
@@ -245,11 +345,11 @@ func RunMySQLDockerInstance(config *DatabaseConfig) (*DatabaseConfig, *dockertes
 		config.MySQL.Password = "secret"
 	}
 
-	resource, err := findOrLaunchMySQLContainer(config)
+	container, port, err := findOrLaunchMySQLContainer(config, image)
 	if err != nil {
 		return nil, nil, err
 	}
-	address := fmt.Sprintf("tcp(localhost:%s)", resource.GetPort("3306/tcp"))
+	address := fmt.Sprintf("tcp(localhost:%s)", port)
 
 	return &DatabaseConfig{
 		DatabaseName: config.DatabaseName,
@@ -258,63 +358,46 @@ func RunMySQLDockerInstance(config *DatabaseConfig) (*DatabaseConfig, *dockertes
 			User:     config.MySQL.User,
 			Password: config.MySQL.Password,
 		},
-	}, resource, nil
+	}, container, nil
 }
 
-func findOrLaunchMySQLContainer(config *DatabaseConfig) (*dockertest.Resource, error) {
-	var containerName = "mysql-test-container"
-	var resource *dockertest.Resource
-	var err error
-
-	pool, err := dockertest.NewPool("")
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = pool.RunWithOptions(&dockertest.RunOptions{
-		Name:       containerName,
-		Repository: "vaulty/mysql-volumeless",
-		Tag:        "8.0",
-		Env: []string{
-			fmt.Sprintf("MYSQL_USER=%s", config.MySQL.User),
-			fmt.Sprintf("MYSQL_PASSWORD=%s", config.MySQL.Password),
-			fmt.Sprintf("MYSQL_ROOT_PASSWORD=%s", config.MySQL.Password),
+func findOrLaunchMySQLContainer(config *DatabaseConfig, image string) (testcontainers.Container, string, error) {
+	const containerName = "mysql-test-container"
+	const mysqlPort = "3306/tcp"
+
+	ctx := context.Background()
+
+	req := testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Name:          containerName,
+			Image:         image,
+			ImagePlatform: os.Getenv("MOOV_TEST_IMAGE_PLATFORM"),
+			ExposedPorts:  []string{mysqlPort},
+			Env: map[string]string{
+				"MYSQL_USER":          config.MySQL.User,
+				"MYSQL_PASSWORD":      config.MySQL.Password,
+				"MYSQL_ROOT_PASSWORD": config.MySQL.Password,
+			},
+			WaitingFor: wait.ForSQL(nat.Port(mysqlPort), "mysql", func(host string, port nat.Port) string {
+				return fmt.Sprintf("%s:%s@tcp(%s:%s)/", config.MySQL.User, config.MySQL.Password, host, port.Port())
+			}).WithStartupTimeout(2 * time.Minute),
 		},
-	})
-
-	if err != nil && !errors.Is(err, dc.ErrContainerAlreadyExists) {
-		return nil, err
+		Reuse:   true,
+		Started: true,
 	}
 
-	// look for running container
-	resource, found := pool.ContainerByName(containerName)
-	if !found {
-		return nil, errors.New("failed to launch (or find) MySQL container")
+	container, err := testcontainers.GenericContainer(ctx, req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to launch (or find) MySQL container: %w", err)
 	}
 
-	address := fmt.Sprintf("tcp(localhost:%s)", resource.GetPort("3306/tcp"))
-
-	dbURL := fmt.Sprintf("%s:%s@%s/%s",
-		config.MySQL.User,
-		config.MySQL.Password,
-		address,
-		config.DatabaseName,
-	)
-
-	err = pool.Retry(func() error {
-		db, err := sql.Open("mysql", dbURL)
-		if err != nil {
-			return err
-		}
-		defer db.Close()
-		return db.Ping()
-	})
+	mapped, err := container.MappedPort(ctx, nat.Port(mysqlPort))
 	if err != nil {
-		resource.Close()
-		return nil, err
+		container.Terminate(ctx)
+		return nil, "", err
 	}
 
-	return resource, nil
+	return container, mapped.Port(), nil
 }
 
 // MySQLUniqueViolation returns true when the provided error matches the MySQL code
@@ -326,3 +409,40 @@ func MySQLUniqueViolation(err error) bool {
 	}
 	return match
 }
+
+// MySQL error codes used by mySQLErrorClassifier.
+// https://dev.mysql.com/doc/refman/8.0/en/server-error-reference.html
+const (
+	mySQLErrDeadlock          uint16 = 1213
+	mySQLErrForeignKeyParent  uint16 = 1452
+	mySQLErrDataTruncatedNull uint16 = 1048
+)
+
+func mysqlErrCode(err error, code uint16) bool {
+	e, ok := err.(*gomysql.MySQLError)
+	return ok && e.Number == code
+}
+
+type mySQLErrorClassifier struct{}
+
+func (mySQLErrorClassifier) UniqueViolation(err error) bool {
+	return MySQLUniqueViolation(err)
+}
+
+func (mySQLErrorClassifier) DeadlockDetected(err error) bool {
+	return mysqlErrCode(err, mySQLErrDeadlock)
+}
+
+// SerializationFailure reports true for MySQL's deadlock code (1213),
+// as MySQL has no separate serialization-failure error the way Postgres does.
+func (mySQLErrorClassifier) SerializationFailure(err error) bool {
+	return mysqlErrCode(err, mySQLErrDeadlock)
+}
+
+func (mySQLErrorClassifier) NotNullViolation(err error) bool {
+	return mysqlErrCode(err, mySQLErrDataTruncatedNull)
+}
+
+func (mySQLErrorClassifier) ForeignKeyViolation(err error) bool {
+	return mysqlErrCode(err, mySQLErrForeignKeyParent)
+}
@@ -0,0 +1,50 @@
+package database
+
+import "testing"
+
+func TestResolveMySQLImage(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		if got := resolveMySQLImage(nil); got != "mysql:8.0" {
+			t.Errorf("resolveMySQLImage(nil) = %q, want %q", got, "mysql:8.0")
+		}
+	})
+
+	t.Run("substitute takes precedence over registry", func(t *testing.T) {
+		t.Setenv("MOOV_TEST_IMAGE_REGISTRY", "registry.example.com")
+
+		substitute := func(image string) string { return "internal/" + image }
+		if got := resolveMySQLImage(substitute); got != "internal/mysql:8.0" {
+			t.Errorf("resolveMySQLImage(substitute) = %q, want %q", got, "internal/mysql:8.0")
+		}
+	})
+
+	t.Run("falls back to registry env var", func(t *testing.T) {
+		t.Setenv("MOOV_TEST_IMAGE_REGISTRY", "registry.example.com")
+
+		if got := resolveMySQLImage(nil); got != "registry.example.com/mysql:8.0" {
+			t.Errorf("resolveMySQLImage(nil) = %q, want %q", got, "registry.example.com/mysql:8.0")
+		}
+	})
+}
+
+func TestExternalMySQLConfig(t *testing.T) {
+	config, err := externalMySQLConfig("root:secret@tcp(localhost:3306)/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.MySQL.User != "root" {
+		t.Errorf("User = %q, want %q", config.MySQL.User, "root")
+	}
+	if config.MySQL.Password != "secret" {
+		t.Errorf("Password = %q, want %q", config.MySQL.Password, "secret")
+	}
+	if config.MySQL.Address != "tcp(localhost:3306)" {
+		t.Errorf("Address = %q, want %q", config.MySQL.Address, "tcp(localhost:3306)")
+	}
+}
+
+func TestExternalMySQLConfig_Invalid(t *testing.T) {
+	if _, err := externalMySQLConfig("not a valid dsn"); err == nil {
+		t.Fatal("expected an error for an invalid DSN")
+	}
+}
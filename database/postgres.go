@@ -0,0 +1,370 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/moov-io/base"
+	"github.com/moov-io/base/docker"
+
+	kitprom "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/lib/pq"
+	stdprom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/darwinz/base/log"
+)
+
+var (
+	postgresConnections = kitprom.NewGaugeFrom(stdprom.GaugeOpts{
+		Name: "postgres_connections",
+		Help: "How many Postgres connections and what status they're in.",
+	}, []string{"state"})
+
+	// postgresErrUniqueViolation is the SQLSTATE code for unique_violation
+	// https://www.postgresql.org/docs/current/errcodes-appendix.html
+	postgresErrUniqueViolation = "23505"
+
+	maxActivePostgresConnections = func() int {
+		if v := os.Getenv("POSTGRES_MAX_CONNECTIONS"); v != "" {
+			if n, _ := strconv.ParseInt(v, 10, 32); n > 0 {
+				return int(n)
+			}
+		}
+		return 16
+	}()
+)
+
+type postgresDB struct {
+	dsn    string
+	logger log.Logger
+	retry  RetryPolicy
+
+	connections *kitprom.Gauge
+}
+
+func (my *postgresDB) Connect(ctx context.Context) (*sql.DB, error) {
+	db, err := sql.Open("postgres", my.dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(maxActivePostgresConnections)
+
+	// Check our DB is up and working, retrying with backoff since the Postgres
+	// sidecar or RDS instance may not be ready yet when the app boots.
+	if err := withRetry(ctx, my.retry, my.logger, db.Ping); err != nil {
+		return nil, err
+	}
+
+	// Setup metrics after the database is setup
+	go func() {
+		t := time.NewTicker(statsLogInterval())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				stats := db.Stats()
+				my.connections.With("state", "idle").Set(float64(stats.Idle))
+				my.connections.With("state", "inuse").Set(float64(stats.InUse))
+				my.connections.With("state", "open").Set(float64(stats.OpenConnections))
+
+				my.logger.Info(ctx, "postgres connection pool stats",
+					log.F("idle", log.Int(stats.Idle)),
+					log.F("inuse", log.Int(stats.InUse)),
+					log.F("open", log.Int(stats.OpenConnections)),
+				)
+			}
+		}
+	}()
+
+	return db, nil
+}
+
+func postgresConnection(logger log.Logger, retry RetryPolicy, user, pass, address, database string) *postgresDB {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+
+	dsn := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable", user, pass, address, database)
+	return &postgresDB{
+		dsn:         dsn,
+		logger:      logger,
+		retry:       retry,
+		connections: postgresConnections,
+	}
+}
+
+// TestPostgresDB is a wrapper around sql.DB for Postgres connections designed for tests to provide
+// a clean database for each testcase. Callers should cleanup with Close() when finished.
+type TestPostgresDB struct {
+	*DB
+	name     string
+	shutdown func() // context shutdown func
+	t        *testing.T
+}
+
+func (r *TestPostgresDB) Close() error {
+	r.shutdown()
+
+	// Verify all connections are closed before closing DB
+	if conns := r.DB.Stats().OpenConnections; conns != 0 {
+		require.FailNow(r.t, ErrOpenConnections{
+			Database:       "postgres",
+			NumConnections: conns,
+		}.Error())
+	}
+
+	_, err := r.DB.Exec(fmt.Sprintf("drop database %s", r.name))
+	if err != nil {
+		return err
+	}
+
+	return r.DB.Close()
+}
+
+var SharedPostgres postgresServer
+
+type postgresServer struct {
+	Config *DatabaseConfig
+
+	start     sync.Once
+	container testcontainers.Container
+
+	substituteImage func(image string) string
+}
+
+// SubstituteImage registers fn to rewrite the Postgres image reference before it's pulled,
+// which lets air-gapped or enterprise CI environments redirect to an internal mirror.
+// If unset, the MOOV_TEST_IMAGE_REGISTRY environment variable is consulted instead.
+func (m *postgresServer) SubstituteImage(fn func(image string) string) {
+	m.substituteImage = fn
+}
+
+func (m *postgresServer) image() string {
+	return resolvePostgresImage(m.substituteImage)
+}
+
+// resolvePostgresImage returns the Postgres image to launch, preferring an explicit
+// substitute func and falling back to MOOV_TEST_IMAGE_REGISTRY.
+func resolvePostgresImage(substitute func(image string) string) string {
+	image := "postgres:16"
+	if substitute != nil {
+		return substitute(image)
+	}
+	if registry := os.Getenv("MOOV_TEST_IMAGE_REGISTRY"); registry != "" {
+		if idx := strings.LastIndex(image, "/"); idx >= 0 {
+			return registry + "/" + image[idx+1:]
+		}
+		return registry + "/" + image
+	}
+	return image
+}
+
+// Start starts the Postgres server or finds the running server (container) we do not stop
+// Postgres server as we can re-use same container during multiple test runs. You
+// can safely stop/remove the Postgres container manually.
+func (m *postgresServer) Start() error {
+	var err error
+
+	m.start.Do(func() {
+		m.Config, m.container, err = runPostgresDockerInstance(&DatabaseConfig{}, m.image())
+	})
+
+	return err
+}
+
+// Stop stops the container and removes linked volumes
+// We don't stop Postgres to reduce startup time for the next test runs
+func (m *postgresServer) Stop() error {
+	return m.container.Terminate(context.Background())
+}
+
+// CreateTestPostgresDB returns a TestPostgresDB which can be used in tests
+// as a clean Postgres database. All migrations are ran on the db before.
+//
+// Callers should call close on the returned *TestPostgresDB.
+func CreateTestPostgresDB(t *testing.T) *TestPostgresDB {
+	if testing.Short() {
+		t.Skip("-short flag enabled")
+	}
+	if !docker.Enabled() {
+		t.Skip("Docker not enabled")
+	}
+
+	err := SharedPostgres.Start()
+	require.NoError(t, err)
+
+	dbName, err := CreateTemporaryPostgresDatabase(SharedPostgres.Config)
+	require.NoError(t, err)
+
+	dbConfig := &DatabaseConfig{
+		DatabaseName: dbName,
+		Postgres:     SharedPostgres.Config.Postgres,
+		Retry:        &FastRetryPolicy,
+	}
+
+	logger := log.NewNopLogger()
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	db, err := NewAndMigrate(ctx, logger, *dbConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Don't allow idle connections so we can verify all are closed at the end of testing
+	db.SetMaxIdleConns(0)
+
+	return &TestPostgresDB{
+		DB:       db,
+		name:     dbName,
+		shutdown: cancelFunc,
+		t:        t,
+	}
+}
+
+// We connect as the superuser to the Postgres server and create a database with a random name to
+// run our migrations on it later.
+func CreateTemporaryPostgresDatabase(config *DatabaseConfig) (string, error) {
+	dsn := fmt.Sprintf("postgres://%s:%s@%s/postgres?sslmode=disable", config.Postgres.User, config.Postgres.Password, config.Postgres.Address)
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	dbName := "test" + base.ID()
+
+	_, err = db.ExecContext(context.Background(), fmt.Sprintf("create database %s", dbName))
+	if err != nil {
+		return "", err
+	}
+
+	return dbName, nil
+}
+
+// RunPostgresDockerInstance launches (or reuses) a Postgres container via testcontainers-go
+// and returns a DatabaseConfig pointed at it, along with the testcontainers.Container
+// (stop it with container.Terminate(ctx)).
+func RunPostgresDockerInstance(config *DatabaseConfig) (*DatabaseConfig, testcontainers.Container, error) {
+	return runPostgresDockerInstance(config, resolvePostgresImage(nil))
+}
+
+func runPostgresDockerInstance(config *DatabaseConfig, image string) (*DatabaseConfig, testcontainers.Container, error) {
+	if config.Postgres == nil {
+		config.Postgres = &PostgresConfig{}
+	}
+
+	if config.Postgres.User == "" {
+		config.Postgres.User = "moov"
+	}
+
+	if config.Postgres.Password == "" {
+		config.Postgres.Password = "secret"
+	}
+
+	container, port, err := findOrLaunchPostgresContainer(config, image)
+	if err != nil {
+		return nil, nil, err
+	}
+	address := fmt.Sprintf("localhost:%s", port)
+
+	return &DatabaseConfig{
+		DatabaseName: config.DatabaseName,
+		Postgres: &PostgresConfig{
+			Address:  address,
+			User:     config.Postgres.User,
+			Password: config.Postgres.Password,
+		},
+	}, container, nil
+}
+
+func findOrLaunchPostgresContainer(config *DatabaseConfig, image string) (testcontainers.Container, string, error) {
+	const containerName = "postgres-test-container"
+	const postgresPort = "5432/tcp"
+
+	ctx := context.Background()
+
+	req := testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Name:          containerName,
+			Image:         image,
+			ImagePlatform: os.Getenv("MOOV_TEST_IMAGE_PLATFORM"),
+			ExposedPorts:  []string{postgresPort},
+			Env: map[string]string{
+				"POSTGRES_USER":     config.Postgres.User,
+				"POSTGRES_PASSWORD": config.Postgres.Password,
+			},
+			WaitingFor: wait.ForSQL(nat.Port(postgresPort), "postgres", func(host string, port nat.Port) string {
+				return fmt.Sprintf("postgres://%s:%s@%s:%s/postgres?sslmode=disable", config.Postgres.User, config.Postgres.Password, host, port.Port())
+			}).WithStartupTimeout(2 * time.Minute),
+		},
+		Reuse:   true,
+		Started: true,
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to launch (or find) Postgres container: %w", err)
+	}
+
+	mapped, err := container.MappedPort(ctx, nat.Port(postgresPort))
+	if err != nil {
+		container.Terminate(ctx)
+		return nil, "", err
+	}
+
+	return container, mapped.Port(), nil
+}
+
+// PostgresUniqueViolation returns true when the provided error matches the Postgres code
+// for duplicate entries (violating a unique table constraint).
+func PostgresUniqueViolation(err error) bool {
+	return postgresErrCode(err, postgresErrUniqueViolation)
+}
+
+// Postgres SQLSTATE codes used by postgresErrorClassifier.
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	postgresErrSerializationFailure = "40001"
+	postgresErrDeadlockDetected     = "40P01"
+	postgresErrNotNullViolation     = "23502"
+	postgresErrForeignKeyViolation  = "23503"
+)
+
+func postgresErrCode(err error, code string) bool {
+	e, ok := err.(*pq.Error)
+	return ok && string(e.Code) == code
+}
+
+type postgresErrorClassifier struct{}
+
+func (postgresErrorClassifier) UniqueViolation(err error) bool {
+	return postgresErrCode(err, postgresErrUniqueViolation)
+}
+
+func (postgresErrorClassifier) DeadlockDetected(err error) bool {
+	return postgresErrCode(err, postgresErrDeadlockDetected)
+}
+
+func (postgresErrorClassifier) SerializationFailure(err error) bool {
+	return postgresErrCode(err, postgresErrSerializationFailure)
+}
+
+func (postgresErrorClassifier) NotNullViolation(err error) bool {
+	return postgresErrCode(err, postgresErrNotNullViolation)
+}
+
+func (postgresErrorClassifier) ForeignKeyViolation(err error) bool {
+	return postgresErrCode(err, postgresErrForeignKeyViolation)
+}
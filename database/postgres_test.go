@@ -0,0 +1,28 @@
+package database
+
+import "testing"
+
+func TestResolvePostgresImage(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		if got := resolvePostgresImage(nil); got != "postgres:16" {
+			t.Errorf("resolvePostgresImage(nil) = %q, want %q", got, "postgres:16")
+		}
+	})
+
+	t.Run("substitute takes precedence over registry", func(t *testing.T) {
+		t.Setenv("MOOV_TEST_IMAGE_REGISTRY", "registry.example.com")
+
+		substitute := func(image string) string { return "internal/" + image }
+		if got := resolvePostgresImage(substitute); got != "internal/postgres:16" {
+			t.Errorf("resolvePostgresImage(substitute) = %q, want %q", got, "internal/postgres:16")
+		}
+	})
+
+	t.Run("falls back to registry env var", func(t *testing.T) {
+		t.Setenv("MOOV_TEST_IMAGE_REGISTRY", "registry.example.com")
+
+		if got := resolvePostgresImage(nil); got != "registry.example.com/postgres:16" {
+			t.Errorf("resolvePostgresImage(nil) = %q, want %q", got, "registry.example.com/postgres:16")
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+
+	kitprom "github.com/go-kit/kit/metrics/prometheus"
+	stdprom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/darwinz/base/log"
+)
+
+var dbConnectRetries = kitprom.NewCounterFrom(stdprom.CounterOpts{
+	Name: "db_connect_retries_total",
+	Help: "How many times we've retried connecting (or migrating) against a database.",
+}, []string{})
+
+// RetryPolicy controls the exponential backoff used when connecting to (or
+// migrating) a database that may not be ready yet, e.g. an RDS instance or
+// sidecar that hasn't finished booting.
+type RetryPolicy struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	MaxAttempts  int
+
+	// Jitter is the fraction (0 to 1) of each delay to randomize, to avoid a
+	// thundering herd of clients retrying in lockstep.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is used whenever a DatabaseConfig doesn't specify its own RetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	InitialDelay: 100 * time.Millisecond,
+	MaxDelay:     10 * time.Second,
+	Multiplier:   2,
+	MaxAttempts:  10,
+	Jitter:       0.2,
+}
+
+// FastRetryPolicy is meant for connecting to a database we already know is up
+// (e.g. a test helper's container that passed its own readiness check) where a
+// failure to connect is almost certainly a config problem, not a cold start.
+var FastRetryPolicy = RetryPolicy{
+	InitialDelay: 10 * time.Millisecond,
+	MaxDelay:     100 * time.Millisecond,
+	Multiplier:   2,
+	MaxAttempts:  3,
+	Jitter:       0.2,
+}
+
+func retryPolicy(config DatabaseConfig) RetryPolicy {
+	if config.Retry != nil {
+		return *config.Retry
+	}
+	return DefaultRetryPolicy
+}
+
+// withRetry calls op, retrying with exponential backoff and jitter according to
+// policy until it succeeds, ctx is canceled, or MaxAttempts is exhausted. logger
+// receives a warn-level message, including the attempt number and error, before
+// each retry.
+//
+// Only transient errors (failing to dial or a timeout) are retried — a
+// permanent error such as bad credentials or an unknown database fails on the
+// first attempt instead of waiting out the full backoff schedule.
+func withRetry(ctx context.Context, policy RetryPolicy, logger log.Logger, op func() error) error {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+
+	delay := policy.InitialDelay
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = op(); err == nil {
+			return nil
+		}
+
+		if !isTransient(err) || attempt >= policy.MaxAttempts {
+			return err
+		}
+
+		dbConnectRetries.Add(1)
+		logger.Warn(ctx, "retrying database connection",
+			log.F("attempt", log.Int(attempt)),
+			log.F("error", log.String(err.Error())),
+		)
+
+		sleep := jitter(delay, policy.Jitter)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+
+		delay = time.Duration(float64(delay) * policy.Multiplier)
+		if delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+}
+
+// isTransient reports whether err looks like the server just isn't reachable
+// yet (connection refused, timeout, ...) as opposed to a permanent failure
+// (bad credentials, unknown database) that no amount of retrying will fix.
+func isTransient(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, driver.ErrBadConn)
+}
+
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	spread := float64(d) * fraction
+	return d + time.Duration(rand.Float64()*2*spread-spread)
+}
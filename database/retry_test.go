@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+type fakeNetErr struct{}
+
+func (fakeNetErr) Error() string   { return "fake net error" }
+func (fakeNetErr) Timeout() bool   { return true }
+func (fakeNetErr) Temporary() bool { return true }
+
+func TestIsTransient(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"net error", fakeNetErr{}, true},
+		{"wrapped net error", fmt.Errorf("dial: %w", fakeNetErr{}), true},
+		{"bad conn", driver.ErrBadConn, true},
+		{"permanent error", errors.New("access denied for user"), false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isTransient(tc.err); got != tc.want {
+				t.Errorf("isTransient(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+
+	var _ net.Error = fakeNetErr{}
+}
+
+func TestWithRetry_StopsOnPermanentError(t *testing.T) {
+	var attempts int
+	err := withRetry(context.Background(), DefaultRetryPolicy, nil, func() error {
+		attempts++
+		return errors.New("bad credentials")
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if attempts != 1 {
+		t.Fatalf("expected a permanent error to stop retrying after 1 attempt, got %d", attempts)
+	}
+}
+
+func TestWithRetry_RetriesTransientError(t *testing.T) {
+	policy := RetryPolicy{
+		InitialDelay: time.Millisecond,
+		MaxDelay:     time.Millisecond,
+		Multiplier:   1,
+		MaxAttempts:  3,
+		Jitter:       0,
+	}
+
+	var attempts int
+	err := withRetry(context.Background(), policy, nil, func() error {
+		attempts++
+		if attempts < 3 {
+			return fakeNetErr{}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected eventual success, got %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestWithRetry_ExhaustsMaxAttempts(t *testing.T) {
+	policy := RetryPolicy{
+		InitialDelay: time.Millisecond,
+		MaxDelay:     time.Millisecond,
+		Multiplier:   1,
+		MaxAttempts:  2,
+		Jitter:       0,
+	}
+
+	var attempts int
+	err := withRetry(context.Background(), policy, nil, func() error {
+		attempts++
+		return fakeNetErr{}
+	})
+	if err == nil {
+		t.Fatal("expected an error once MaxAttempts is exhausted")
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	d := 100 * time.Millisecond
+
+	if got := jitter(d, 0); got != d {
+		t.Fatalf("jitter with 0 fraction should return d unchanged, got %v", got)
+	}
+
+	for i := 0; i < 20; i++ {
+		got := jitter(d, 0.5)
+		if got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("jitter(%v, 0.5) = %v, out of expected range", d, got)
+		}
+	}
+}
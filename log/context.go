@@ -0,0 +1,40 @@
+package log
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestIDContextKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying requestID, which
+// WithContext will pick up automatically.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDContextKey{}).(string)
+	return requestID, ok
+}
+
+// contextFields extracts the trace/span and request IDs found in ctx as Fields,
+// for WithContext to bake into a child Logger.
+func contextFields(ctx context.Context) []Field {
+	var fields []Field
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			F("trace_id", String(sc.TraceID().String())),
+			F("span_id", String(sc.SpanID().String())),
+		)
+	}
+
+	if requestID, ok := RequestIDFromContext(ctx); ok && requestID != "" {
+		fields = append(fields, F("request_id", String(requestID)))
+	}
+
+	return fields
+}
@@ -0,0 +1,134 @@
+package log
+
+import (
+	"context"
+	"io"
+
+	kitlog "github.com/go-kit/log"
+)
+
+// Field pairs a key with a Valuer and is the unit passed to Logger methods
+// and With() for structured, leveled logging.
+type Field struct {
+	Key   string
+	Value Valuer
+}
+
+// F builds a Field for use with Logger methods, e.g. logger.Info(ctx, "connected", log.F("address", log.String(addr))).
+func F(key string, v Valuer) Field {
+	return Field{Key: key, Value: v}
+}
+
+// LogPairs flattens a map of fields into a slice, for callers that build up fields
+// dynamically rather than via F().
+func LogPairs(fields map[string]Valuer) []Field {
+	out := make([]Field, 0, len(fields))
+	for k, v := range fields {
+		out = append(out, Field{Key: k, Value: v})
+	}
+	return out
+}
+
+// Logger is a leveled, context-aware structured logger.
+type Logger interface {
+	Debug(ctx context.Context, msg string, keyvals ...Field)
+	Info(ctx context.Context, msg string, keyvals ...Field)
+	Warn(ctx context.Context, msg string, keyvals ...Field)
+	Error(ctx context.Context, msg string, keyvals ...Field)
+	Log(ctx context.Context, level Level, msg string, keyvals ...Field)
+
+	// With returns a child Logger that always includes keyvals in addition to
+	// whatever is passed to its own logging calls.
+	With(keyvals ...Field) Logger
+
+	// WithContext returns a child Logger with trace/span and request IDs found
+	// in ctx (if any) baked in as fields.
+	WithContext(ctx context.Context) Logger
+}
+
+type logger struct {
+	kit   kitlog.Logger
+	level Level
+}
+
+// NewJSONLogger returns a Logger that writes one JSON object per line to w.
+func NewJSONLogger(w io.Writer) Logger {
+	return &logger{kit: kitlog.NewJSONLogger(w)}
+}
+
+// NewLogfmtLogger returns a Logger that writes logfmt-formatted lines to w.
+func NewLogfmtLogger(w io.Writer) Logger {
+	return &logger{kit: kitlog.NewLogfmtLogger(w)}
+}
+
+// NewNopLogger returns a Logger that discards everything written to it.
+func NewNopLogger() Logger {
+	return &logger{kit: kitlog.NewNopLogger()}
+}
+
+// NewLevelFilter returns a copy of l that drops log lines below min. Only
+// Loggers built by this package are filtered; any other Logger is returned
+// unchanged and logs at every level.
+func NewLevelFilter(min Level, l Logger) Logger {
+	impl, ok := l.(*logger)
+	if !ok {
+		return l
+	}
+	cp := *impl
+	cp.level = min
+	return &cp
+}
+
+func (l *logger) Debug(ctx context.Context, msg string, keyvals ...Field) {
+	l.Log(ctx, LevelDebug, msg, keyvals...)
+}
+
+func (l *logger) Info(ctx context.Context, msg string, keyvals ...Field) {
+	l.Log(ctx, LevelInfo, msg, keyvals...)
+}
+
+func (l *logger) Warn(ctx context.Context, msg string, keyvals ...Field) {
+	l.Log(ctx, LevelWarn, msg, keyvals...)
+}
+
+func (l *logger) Error(ctx context.Context, msg string, keyvals ...Field) {
+	l.Log(ctx, LevelError, msg, keyvals...)
+}
+
+func (l *logger) Log(ctx context.Context, level Level, msg string, keyvals ...Field) {
+	if level < l.level {
+		return
+	}
+
+	pairs := make([]interface{}, 0, 2+2*(len(keyvals)+1))
+	pairs = append(pairs, "level", level.String(), "msg", msg)
+	for _, kv := range keyvals {
+		pairs = append(pairs, kv.Key, kv.Value.getValue())
+	}
+
+	l.kit.Log(pairs...)
+}
+
+func (l *logger) With(keyvals ...Field) Logger {
+	if len(keyvals) == 0 {
+		return l
+	}
+
+	pairs := make([]interface{}, 0, 2*len(keyvals))
+	for _, kv := range keyvals {
+		pairs = append(pairs, kv.Key, kv.Value.getValue())
+	}
+
+	return &logger{
+		kit:   kitlog.With(l.kit, pairs...),
+		level: l.level,
+	}
+}
+
+func (l *logger) WithContext(ctx context.Context) Logger {
+	fields := contextFields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields...)
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLogger_Levels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLevelFilter(LevelWarn, NewLogfmtLogger(&buf))
+
+	logger.Debug(context.Background(), "debug msg")
+	logger.Info(context.Background(), "info msg")
+	if buf.Len() != 0 {
+		t.Fatalf("expected debug/info to be filtered out, got: %q", buf.String())
+	}
+
+	logger.Warn(context.Background(), "warn msg")
+	if !strings.Contains(buf.String(), "warn msg") {
+		t.Fatalf("expected warn msg to be logged, got: %q", buf.String())
+	}
+
+	buf.Reset()
+	logger.Error(context.Background(), "error msg")
+	if !strings.Contains(buf.String(), "error msg") {
+		t.Fatalf("expected error msg to be logged, got: %q", buf.String())
+	}
+}
+
+func TestLogger_With(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogfmtLogger(&buf)
+
+	logger = logger.With(F("request_id", String("abc123")))
+	logger.Info(context.Background(), "hello", F("key", String("value")))
+
+	out := buf.String()
+	if !strings.Contains(out, "request_id=abc123") {
+		t.Fatalf("expected With() field in output, got: %q", out)
+	}
+	if !strings.Contains(out, "key=value") {
+		t.Fatalf("expected call-site field in output, got: %q", out)
+	}
+}
+
+func TestLogger_WithContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogfmtLogger(&buf)
+
+	ctx := ContextWithRequestID(context.Background(), "req-42")
+	logger.WithContext(ctx).Info(ctx, "hello")
+
+	if !strings.Contains(buf.String(), "request_id=req-42") {
+		t.Fatalf("expected request_id from context in output, got: %q", buf.String())
+	}
+}
+
+func TestLogger_WithContext_NoFields(t *testing.T) {
+	logger := NewNopLogger()
+
+	// With no trace/request ID on the context, WithContext should be a no-op
+	// and return the same logger rather than an unnecessary wrapper.
+	if got := logger.WithContext(context.Background()); got != logger {
+		t.Fatalf("expected WithContext to return the same logger when ctx has no fields")
+	}
+}